@@ -0,0 +1,186 @@
+// Package exp publishes a metrics.Registry both as individual expvar.Var
+// entries and as a single JSON document served at /debug/metrics, in the
+// same spirit as the standard library's net/http/pprof debug surface.
+package exp
+
+import (
+	"encoding/json"
+	"expvar"
+	"net/http"
+	"sync"
+
+	"github.com/mia0x75/go-metrics"
+)
+
+// Exp publishes every metric in r as an expvar.Var and installs
+// ExpHandler(r) at "/debug/metrics" on http.DefaultServeMux.
+func Exp(r metrics.Registry) {
+	e := &exp{registry: r, published: make(map[string]bool), publishVars: true}
+	e.publish()
+	http.Handle("/debug/metrics", http.HandlerFunc(e.handle))
+}
+
+// ExpHandler returns an http.Handler that renders every metric in r as a
+// stable JSON document, without touching expvar or http.DefaultServeMux.
+// Callers that want to mount it at a path other than /debug/metrics, or
+// that manage their own ServeMux, should use this instead of Exp.
+func ExpHandler(r metrics.Registry) http.Handler {
+	e := &exp{registry: r, published: make(map[string]bool)}
+	return http.HandlerFunc(e.handle)
+}
+
+// exp adapts a metrics.Registry to expvar and to plain JSON. expvar panics
+// if the same name is published twice, so publish tracks what has already
+// been registered and is safe to call repeatedly.
+type exp struct {
+	lock      sync.Mutex
+	registry  metrics.Registry
+	published map[string]bool
+	// publishVars makes handle publish newly registered metrics to expvar
+	// before rendering. Only Exp sets it.
+	publishVars bool
+}
+
+// publish walks the registry through its typed Each* iterators (the only
+// iteration Registry exposes) and expvar.Publishes any metric it hasn't
+// seen before.
+func (e *exp) publish() {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	e.registry.EachCounter(func(name string, m metrics.Counter) {
+		e.publishOnce(name, func() interface{} { return counterJSON(m) })
+	})
+	e.registry.EachGauge(func(name string, m metrics.Gauge) {
+		e.publishOnce(name, func() interface{} { return gaugeJSON(m) })
+	})
+	e.registry.EachGaugeFloat64(func(name string, m metrics.GaugeFloat64) {
+		e.publishOnce(name, func() interface{} { return gaugeFloat64JSON(m) })
+	})
+	e.registry.EachHealthcheck(func(name string, m metrics.Healthcheck) {
+		e.publishOnce(name, func() interface{} { return healthcheckJSON(m) })
+	})
+	e.registry.EachHistogram(func(name string, m metrics.Histogram) {
+		e.publishOnce(name, func() interface{} { return histogramJSON(m) })
+	})
+	e.registry.EachMeter(func(name string, m metrics.Meter) {
+		e.publishOnce(name, func() interface{} { return meterJSON(m) })
+	})
+	e.registry.EachTimer(func(name string, m metrics.Timer) {
+		e.publishOnce(name, func() interface{} { return timerJSON(m) })
+	})
+	e.registry.EachResettingTimer(func(name string, m metrics.ResettingTimer) {
+		e.publishOnce(name, func() interface{} { return resettingTimerJSON(m) })
+	})
+}
+
+// publishOnce expvar.Publishes f under name the first time it is seen.
+// Must be called with e.lock held.
+func (e *exp) publishOnce(name string, f func() interface{}) {
+	if e.published[name] {
+		return
+	}
+	e.published[name] = true
+	expvar.Publish(name, expvar.Func(f))
+}
+
+func (e *exp) handle(w http.ResponseWriter, r *http.Request) {
+	if e.publishVars {
+		e.publish()
+	}
+
+	doc := make(map[string]interface{})
+	e.registry.EachCounter(func(name string, m metrics.Counter) { doc[name] = counterJSON(m) })
+	e.registry.EachGauge(func(name string, m metrics.Gauge) { doc[name] = gaugeJSON(m) })
+	e.registry.EachGaugeFloat64(func(name string, m metrics.GaugeFloat64) { doc[name] = gaugeFloat64JSON(m) })
+	e.registry.EachHealthcheck(func(name string, m metrics.Healthcheck) { doc[name] = healthcheckJSON(m) })
+	e.registry.EachHistogram(func(name string, m metrics.Histogram) { doc[name] = histogramJSON(m) })
+	e.registry.EachMeter(func(name string, m metrics.Meter) { doc[name] = meterJSON(m) })
+	e.registry.EachTimer(func(name string, m metrics.Timer) { doc[name] = timerJSON(m) })
+	e.registry.EachResettingTimer(func(name string, m metrics.ResettingTimer) { doc[name] = resettingTimerJSON(m) })
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	json.NewEncoder(w).Encode(doc)
+}
+
+func counterJSON(m metrics.Counter) interface{} {
+	return map[string]interface{}{"count": m.Count()}
+}
+
+func gaugeJSON(m metrics.Gauge) interface{} {
+	return map[string]interface{}{"value": m.Value()}
+}
+
+func gaugeFloat64JSON(m metrics.GaugeFloat64) interface{} {
+	return map[string]interface{}{"value": m.Value()}
+}
+
+func healthcheckJSON(m metrics.Healthcheck) interface{} {
+	m.Check()
+	errStr := ""
+	if err := m.Error(); err != nil {
+		errStr = err.Error()
+	}
+	return map[string]interface{}{"error": errStr}
+}
+
+func histogramJSON(m metrics.Histogram) interface{} {
+	ps := m.Percentiles([]float64{0.5, 0.75, 0.95, 0.99, 0.999})
+	return map[string]interface{}{
+		"count":  m.Count(),
+		"min":    m.Min(),
+		"max":    m.Max(),
+		"mean":   m.Mean(),
+		"stddev": m.StdDev(),
+		"p50":    ps[0],
+		"p75":    ps[1],
+		"p95":    ps[2],
+		"p99":    ps[3],
+		"p999":   ps[4],
+	}
+}
+
+func meterJSON(m metrics.Meter) interface{} {
+	return map[string]interface{}{
+		"count":     m.Count(),
+		"1m.rate":   m.Rate1(),
+		"5m.rate":   m.Rate5(),
+		"15m.rate":  m.Rate15(),
+		"mean.rate": m.RateMean(),
+	}
+}
+
+func timerJSON(m metrics.Timer) interface{} {
+	ps := m.Percentiles([]float64{0.5, 0.75, 0.95, 0.99, 0.999})
+	return map[string]interface{}{
+		"count":     m.Count(),
+		"min":       m.Min(),
+		"max":       m.Max(),
+		"mean":      m.Mean(),
+		"stddev":    m.StdDev(),
+		"p50":       ps[0],
+		"p75":       ps[1],
+		"p95":       ps[2],
+		"p99":       ps[3],
+		"p999":      ps[4],
+		"1m.rate":   m.Rate1(),
+		"5m.rate":   m.Rate5(),
+		"15m.rate":  m.Rate15(),
+		"mean.rate": m.RateMean(),
+	}
+}
+
+func resettingTimerJSON(m metrics.ResettingTimer) interface{} {
+	s := m.Snapshot()
+	values := s.Values()
+	ps := s.Percentiles([]float64{50, 75, 95, 99, 99.9})
+	return map[string]interface{}{
+		"count": len(values),
+		"mean":  s.Mean(),
+		"p50":   ps[0],
+		"p75":   ps[1],
+		"p95":   ps[2],
+		"p99":   ps[3],
+		"p999":  ps[4],
+	}
+}
@@ -0,0 +1,61 @@
+package exp
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mia0x75/go-metrics"
+)
+
+func TestExpHandlerRendersJSON(t *testing.T) {
+	r := metrics.NewRegistry()
+	metrics.GetOrRegisterCounter("exp.test.counter", r).Inc(5)
+	metrics.GetOrRegisterGauge("exp.test.gauge", r).Update(42)
+
+	req := httptest.NewRequest("GET", "/debug/metrics", nil)
+	w := httptest.NewRecorder()
+	ExpHandler(r).ServeHTTP(w, req)
+
+	if ct := w.Header().Get("Content-Type"); ct != "application/json; charset=utf-8" {
+		t.Fatalf("Content-Type = %q, want application/json; charset=utf-8", ct)
+	}
+
+	var doc map[string]map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("response body is not valid JSON: %v (%s)", err, w.Body.String())
+	}
+
+	counter, ok := doc["exp.test.counter"]
+	if !ok {
+		t.Fatalf("response missing exp.test.counter: %v", doc)
+	}
+	if got := counter["count"]; got != float64(5) {
+		t.Fatalf("counter count = %v, want 5", got)
+	}
+
+	gauge, ok := doc["exp.test.gauge"]
+	if !ok {
+		t.Fatalf("response missing exp.test.gauge: %v", doc)
+	}
+	if got := gauge["value"]; got != float64(42) {
+		t.Fatalf("gauge value = %v, want 42", got)
+	}
+}
+
+func TestExpPublishIsIdempotent(t *testing.T) {
+	r := metrics.NewRegistry()
+	metrics.GetOrRegisterCounter("exp.test.idempotent", r).Inc(1)
+
+	e := &exp{registry: r, published: make(map[string]bool)}
+
+	// expvar.Publish panics if the same name is registered twice; calling
+	// publish() repeatedly (as Exp's handler does on every request) must not
+	// re-publish a name it has already seen.
+	e.publish()
+	e.publish()
+
+	if !e.published["exp.test.idempotent"] {
+		t.Fatalf("expected exp.test.idempotent to be marked as published")
+	}
+}
@@ -0,0 +1,192 @@
+// Package graphite provides a reporter that periodically flushes a
+// metrics.Registry to a Graphite carbon endpoint using the plaintext
+// protocol.
+package graphite
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mia0x75/go-metrics"
+)
+
+// maxBackoff bounds the delay between attempts to dial the carbon endpoint:
+// dialWithBackoff gives up once the next delay would exceed it.
+const maxBackoff = 30 * time.Second
+
+// transport is the minimal surface graphite writes to. Production use
+// dials a real TCP connection; tests can supply a transport backed by a
+// bytes.Buffer to capture the payload without a socket.
+type transport interface {
+	Write([]byte) (int, error)
+	Close() error
+}
+
+// GraphiteConfig provides a container with configuration parameters for
+// the Graphite exporter.
+type GraphiteConfig struct {
+	Addr          *net.TCPAddr     // Network address to connect to
+	Registry      metrics.Registry // Registry to be exported
+	FlushInterval time.Duration    // Flush interval
+	DurationUnit  time.Duration    // Time conversion unit for durations
+	Prefix        string           // Prefix to be prepended to metric names
+	Percentiles   []float64        // Percentiles to export from timers and histograms
+	Logger        *log.Logger      // Logger to report connection/write errors to, defaults to a new logger writing to os.Stderr
+}
+
+// Graphite is a blocking exporter function which reports metrics in r to a
+// graphite server every d interval, prefixing metric names with prefix. It
+// returns the first error encountered, at which point it stops reporting.
+func Graphite(r metrics.Registry, d time.Duration, prefix string, addr *net.TCPAddr) error {
+	return GraphiteWithConfig(GraphiteConfig{
+		Addr:          addr,
+		Registry:      r,
+		FlushInterval: d,
+		DurationUnit:  time.Nanosecond,
+		Prefix:        prefix,
+		Percentiles:   []float64{0.5, 0.75, 0.95, 0.99, 0.999},
+	})
+}
+
+// GraphiteWithConfig is a blocking exporter function just like Graphite,
+// but it takes a GraphiteConfig instead. It returns the first error
+// encountered, at which point it stops reporting.
+func GraphiteWithConfig(c GraphiteConfig) error {
+	if metrics.UseNilMetrics {
+		return nil
+	}
+	if c.Logger == nil {
+		c.Logger = log.New(os.Stderr, "", log.LstdFlags)
+	}
+	if c.Percentiles == nil {
+		c.Percentiles = []float64{0.5, 0.75, 0.95, 0.99, 0.999}
+	}
+
+	for range time.Tick(c.FlushInterval) {
+		if err := graphite(&c, dialTransport); err != nil {
+			c.Logger.Printf("unable to write metrics to graphite. err=%v", err)
+			return err
+		}
+	}
+	return nil
+}
+
+// dialTransport opens a real TCP connection to addr.
+func dialTransport(addr *net.TCPAddr) (transport, error) {
+	return net.DialTCP("tcp", nil, addr)
+}
+
+func graphite(c *GraphiteConfig, dial func(*net.TCPAddr) (transport, error)) error {
+	conn, err := dialWithBackoff(c, dial)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	w := bufio.NewWriter(conn)
+	now := time.Now().Unix()
+	du := float64(c.DurationUnit)
+
+	c.Registry.EachCounter(func(name string, m metrics.Counter) {
+		fmt.Fprintf(w, "%s.%s.count %d %d\n", c.Prefix, name, m.Count(), now)
+	})
+
+	c.Registry.EachGauge(func(name string, m metrics.Gauge) {
+		fmt.Fprintf(w, "%s.%s.value %d %d\n", c.Prefix, name, m.Value(), now)
+	})
+
+	c.Registry.EachGaugeFloat64(func(name string, m metrics.GaugeFloat64) {
+		fmt.Fprintf(w, "%s.%s.value %f %d\n", c.Prefix, name, m.Value(), now)
+	})
+
+	c.Registry.EachHistogram(func(name string, m metrics.Histogram) {
+		ps := m.Percentiles(c.Percentiles)
+		fmt.Fprintf(w, "%s.%s.count %d %d\n", c.Prefix, name, m.Count(), now)
+		fmt.Fprintf(w, "%s.%s.min %d %d\n", c.Prefix, name, m.Min(), now)
+		fmt.Fprintf(w, "%s.%s.max %d %d\n", c.Prefix, name, m.Max(), now)
+		fmt.Fprintf(w, "%s.%s.mean %.2f %d\n", c.Prefix, name, m.Mean(), now)
+		fmt.Fprintf(w, "%s.%s.std-dev %.2f %d\n", c.Prefix, name, m.StdDev(), now)
+		for i, p := range c.Percentiles {
+			fmt.Fprintf(w, "%s.%s.%s %.2f %d\n", c.Prefix, name, percentileLabel(p), ps[i], now)
+		}
+	})
+
+	c.Registry.EachMeter(func(name string, m metrics.Meter) {
+		fmt.Fprintf(w, "%s.%s.count %d %d\n", c.Prefix, name, m.Count(), now)
+		fmt.Fprintf(w, "%s.%s.m1_rate %.2f %d\n", c.Prefix, name, m.Rate1(), now)
+		fmt.Fprintf(w, "%s.%s.m5_rate %.2f %d\n", c.Prefix, name, m.Rate5(), now)
+		fmt.Fprintf(w, "%s.%s.m15_rate %.2f %d\n", c.Prefix, name, m.Rate15(), now)
+		fmt.Fprintf(w, "%s.%s.mean_rate %.2f %d\n", c.Prefix, name, m.RateMean(), now)
+	})
+
+	c.Registry.EachTimer(func(name string, m metrics.Timer) {
+		ps := m.Percentiles(c.Percentiles)
+		fmt.Fprintf(w, "%s.%s.count %d %d\n", c.Prefix, name, m.Count(), now)
+		fmt.Fprintf(w, "%s.%s.min %d %d\n", c.Prefix, name, int64(float64(m.Min())/du), now)
+		fmt.Fprintf(w, "%s.%s.max %d %d\n", c.Prefix, name, int64(float64(m.Max())/du), now)
+		fmt.Fprintf(w, "%s.%s.mean %.2f %d\n", c.Prefix, name, m.Mean()/du, now)
+		fmt.Fprintf(w, "%s.%s.std-dev %.2f %d\n", c.Prefix, name, m.StdDev()/du, now)
+		for i, p := range c.Percentiles {
+			fmt.Fprintf(w, "%s.%s.%s %.2f %d\n", c.Prefix, name, percentileLabel(p), ps[i]/du, now)
+		}
+		fmt.Fprintf(w, "%s.%s.m1_rate %.2f %d\n", c.Prefix, name, m.Rate1(), now)
+		fmt.Fprintf(w, "%s.%s.m5_rate %.2f %d\n", c.Prefix, name, m.Rate5(), now)
+		fmt.Fprintf(w, "%s.%s.m15_rate %.2f %d\n", c.Prefix, name, m.Rate15(), now)
+		fmt.Fprintf(w, "%s.%s.mean_rate %.2f %d\n", c.Prefix, name, m.RateMean(), now)
+	})
+
+	c.Registry.EachResettingTimer(func(name string, m metrics.ResettingTimer) {
+		s := m.Snapshot()
+		// Unlike Histogram/Timer, ResettingTimer.Percentiles takes a 0-100
+		// scale, so c.Percentiles (0-1 fractions) needs converting.
+		ps := s.Percentiles(fractionsToPercents(c.Percentiles))
+		fmt.Fprintf(w, "%s.%s.count %d %d\n", c.Prefix, name, len(s.Values()), now)
+		fmt.Fprintf(w, "%s.%s.mean %.2f %d\n", c.Prefix, name, s.Mean()/du, now)
+		for i, p := range c.Percentiles {
+			fmt.Fprintf(w, "%s.%s.%s %.2f %d\n", c.Prefix, name, percentileLabel(p), ps[i]/du, now)
+		}
+	})
+
+	return w.Flush()
+}
+
+// dialWithBackoff dials the configured transport, retrying on failure with
+// a delay that starts at 100ms and doubles each time. Once the next delay
+// would exceed maxBackoff it stops and returns the last dial error.
+func dialWithBackoff(c *GraphiteConfig, dial func(*net.TCPAddr) (transport, error)) (transport, error) {
+	backoff := 100 * time.Millisecond
+	var lastErr error
+	for backoff <= maxBackoff {
+		conn, err := dial(c.Addr)
+		if err == nil {
+			return conn, nil
+		}
+		lastErr = err
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+	return nil, lastErr
+}
+
+// percentileLabel renders a percentile such as 0.95 as the Coda-Hale-style
+// key suffix "p95", and 0.999 as "p999".
+func percentileLabel(p float64) string {
+	s := strconv.FormatFloat(p*100, 'f', -1, 64)
+	return "p" + strings.Replace(s, ".", "", 1)
+}
+
+// fractionsToPercents converts 0-1 percentile fractions (0.95) to the 0-100
+// scale ResettingTimer.Percentiles expects (95).
+func fractionsToPercents(fractions []float64) []float64 {
+	percents := make([]float64, len(fractions))
+	for i, f := range fractions {
+		percents[i] = f * 100
+	}
+	return percents
+}
@@ -0,0 +1,43 @@
+package graphite
+
+import (
+	"bytes"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/mia0x75/go-metrics"
+)
+
+// bufTransport adapts a bytes.Buffer to the transport interface so tests
+// can inspect the payload graphite() would have sent over a socket.
+type bufTransport struct {
+	bytes.Buffer
+}
+
+func (bufTransport) Close() error { return nil }
+
+func TestGraphiteWritesCounterPayload(t *testing.T) {
+	r := metrics.NewRegistry()
+	c := metrics.GetOrRegisterCounter("requests", r)
+	c.Inc(42)
+
+	buf := &bufTransport{}
+	cfg := GraphiteConfig{
+		Addr:          &net.TCPAddr{},
+		Registry:      r,
+		Prefix:        "myapp",
+		Percentiles:   []float64{0.5, 0.95, 0.99},
+	}
+
+	if err := graphite(&cfg, func(*net.TCPAddr) (transport, error) {
+		return buf, nil
+	}); err != nil {
+		t.Fatalf("graphite() returned an error: %v", err)
+	}
+
+	out := buf.String()
+	if !strings.Contains(out, "myapp.requests.count 42 ") {
+		t.Fatalf("expected counter line in payload, got: %q", out)
+	}
+}
@@ -0,0 +1,98 @@
+package influxdb
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// maxWriteAttempts bounds the total number of attempts, the first one
+// included, made for a write that keeps failing with transient errors
+// before it is given up on for that tick.
+const maxWriteAttempts = 3
+
+// initialBackoff is the delay before the first retry; it doubles on each
+// subsequent attempt.
+const initialBackoff = 500 * time.Millisecond
+
+// influxDBClient writes line-protocol payloads to an InfluxDB HTTP write
+// endpoint, retrying transient failures with exponential backoff.
+type influxDBClient struct {
+	writeURL string
+	username string
+	password string
+	http     *http.Client
+}
+
+func newInfluxDBClient(base url.URL, database, username, password string) *influxDBClient {
+	q := base.Query()
+	q.Set("db", database)
+	base.Path = "/write"
+	base.RawQuery = q.Encode()
+
+	return &influxDBClient{
+		writeURL: base.String(),
+		username: username,
+		password: password,
+		http:     &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// permanentError wraps a failure that retrying will not fix, such as a 4xx
+// response (bad database name, auth failure, malformed line protocol).
+// writeWithRetry fails fast on it instead of burning through its attempts.
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+
+// writeWithRetry POSTs payload to InfluxDB, retrying transient (5xx or
+// network) errors with exponential backoff. A permanent (4xx) error is
+// returned immediately without being retried.
+func (c *influxDBClient) writeWithRetry(payload []byte) error {
+	backoff := initialBackoff
+	var err error
+	for attempt := 1; attempt <= maxWriteAttempts; attempt++ {
+		if err = c.write(payload); err == nil {
+			return nil
+		}
+		if perm, ok := err.(*permanentError); ok {
+			return perm
+		}
+		if attempt == maxWriteAttempts {
+			break
+		}
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+	return fmt.Errorf("influxdb: write failed after %d attempts: %v", maxWriteAttempts, err)
+}
+
+func (c *influxDBClient) write(payload []byte) error {
+	req, err := http.NewRequest("POST", c.writeURL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	if c.username != "" {
+		req.SetBasicAuth(c.username, c.password)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		// A network-level failure (connection refused, timeout, ...) is
+		// transient and worth retrying.
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 500 {
+		return fmt.Errorf("influxdb: unexpected status %s", resp.Status)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return &permanentError{fmt.Errorf("influxdb: unexpected status %s", resp.Status)}
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package influxdb
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+)
+
+func TestWriteWithRetryFailsFastOn4xx(t *testing.T) {
+	var requests int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer srv.Close()
+
+	u, err := url.Parse(srv.URL)
+	if err != nil {
+		t.Fatalf("url.Parse: %v", err)
+	}
+	c := newInfluxDBClient(*u, "db", "", "")
+
+	if err := c.writeWithRetry([]byte("m value=1 1\n")); err == nil {
+		t.Fatal("expected writeWithRetry to return an error for a 400 response")
+	}
+
+	if got := atomic.LoadInt32(&requests); got != 1 {
+		t.Fatalf("server received %d requests, want 1 (no retries on a permanent error)", got)
+	}
+}
+
+func TestWriteWithRetryRetriesOn5xx(t *testing.T) {
+	var requests int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	u, err := url.Parse(srv.URL)
+	if err != nil {
+		t.Fatalf("url.Parse: %v", err)
+	}
+	c := newInfluxDBClient(*u, "db", "", "")
+
+	if err := c.writeWithRetry([]byte("m value=1 1\n")); err == nil {
+		t.Fatal("expected writeWithRetry to return an error when every attempt sees a 500")
+	}
+
+	if got := atomic.LoadInt32(&requests); got != maxWriteAttempts {
+		t.Fatalf("server received %d requests, want %d (retried on a transient error)", got, maxWriteAttempts)
+	}
+}
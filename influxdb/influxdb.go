@@ -0,0 +1,202 @@
+// Package influxdb periodically flushes a metrics.Registry to InfluxDB
+// using the line protocol over HTTP.
+package influxdb
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"net/url"
+	"time"
+
+	"github.com/mia0x75/go-metrics"
+)
+
+// reporter flushes the contents of a Registry to InfluxDB on an interval.
+type reporter struct {
+	reg      metrics.Registry
+	interval time.Duration
+
+	url      url.URL
+	database string
+	username string
+	password string
+	tags     map[string]string
+
+	client writer
+	clock  clock
+}
+
+// writer is the minimal surface reporter writes a flushed batch to.
+// Production use goes through *influxDBClient; tests can supply a writer
+// that captures the payload without making an HTTP request.
+type writer interface {
+	writeWithRetry([]byte) error
+}
+
+// clock abstracts time.Now so tests can supply a deterministic clock.
+type clock interface {
+	Now() time.Time
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time { return time.Now() }
+
+// InfluxDB starts a reporter that writes r's metrics to an InfluxDB server
+// at the given url/database every interval, until the process exits.
+func InfluxDB(r metrics.Registry, interval time.Duration, url, database, username, password string) {
+	WithTags(r, interval, url, database, username, password, nil)
+}
+
+// WithTags behaves like InfluxDB but attaches tags to every measurement it
+// writes.
+func WithTags(r metrics.Registry, interval time.Duration, u, database, username, password string, tags map[string]string) {
+	if metrics.UseNilMetrics {
+		return
+	}
+
+	parsed, err := url.Parse(u)
+	if err != nil {
+		log.Printf("unable to parse InfluxDB url %s. err=%v", u, err)
+		return
+	}
+
+	rep := &reporter{
+		reg:      r,
+		interval: interval,
+		url:      *parsed,
+		database: database,
+		username: username,
+		password: password,
+		tags:     tags,
+		clock:    realClock{},
+	}
+	rep.client = newInfluxDBClient(rep.url, rep.database, rep.username, rep.password)
+	rep.run()
+}
+
+func (r *reporter) run() {
+	intervalTicker := time.NewTicker(r.interval)
+	defer intervalTicker.Stop()
+
+	for range intervalTicker.C {
+		if err := r.send(); err != nil {
+			log.Printf("unable to send metrics to InfluxDB. err=%v", err)
+		}
+	}
+}
+
+func (r *reporter) send() error {
+	var buf bytes.Buffer
+	now := r.clock.Now()
+
+	r.reg.EachCounter(func(name string, c metrics.Counter) {
+		r.writeLine(&buf, name, now, map[string]string{
+			"count": fmt.Sprintf("%d", c.Count()),
+		})
+	})
+
+	r.reg.EachGauge(func(name string, g metrics.Gauge) {
+		r.writeLine(&buf, name, now, map[string]string{
+			"value": fmt.Sprintf("%d", g.Value()),
+		})
+	})
+
+	r.reg.EachGaugeFloat64(func(name string, g metrics.GaugeFloat64) {
+		r.writeLine(&buf, name, now, map[string]string{
+			"value": fmt.Sprintf("%f", g.Value()),
+		})
+	})
+
+	r.reg.EachHistogram(func(name string, h metrics.Histogram) {
+		ps := h.Percentiles([]float64{0.5, 0.75, 0.95, 0.99, 0.999})
+		r.writeLine(&buf, name, now, map[string]string{
+			"count":  fmt.Sprintf("%d", h.Count()),
+			"min":    fmt.Sprintf("%d", h.Min()),
+			"max":    fmt.Sprintf("%d", h.Max()),
+			"mean":   fmt.Sprintf("%f", h.Mean()),
+			"stddev": fmt.Sprintf("%f", h.StdDev()),
+			"p50":    fmt.Sprintf("%f", ps[0]),
+			"p75":    fmt.Sprintf("%f", ps[1]),
+			"p95":    fmt.Sprintf("%f", ps[2]),
+			"p99":    fmt.Sprintf("%f", ps[3]),
+			"p999":   fmt.Sprintf("%f", ps[4]),
+		})
+	})
+
+	r.reg.EachMeter(func(name string, m metrics.Meter) {
+		r.writeLine(&buf, name, now, map[string]string{
+			"count": fmt.Sprintf("%d", m.Count()),
+			"m1":    fmt.Sprintf("%f", m.Rate1()),
+			"m5":    fmt.Sprintf("%f", m.Rate5()),
+			"m15":   fmt.Sprintf("%f", m.Rate15()),
+			"mean":  fmt.Sprintf("%f", m.RateMean()),
+		})
+	})
+
+	r.reg.EachTimer(func(name string, t metrics.Timer) {
+		ps := t.Percentiles([]float64{0.5, 0.75, 0.95, 0.99, 0.999})
+		r.writeLine(&buf, name, now, map[string]string{
+			"count":     fmt.Sprintf("%d", t.Count()),
+			"min":       fmt.Sprintf("%d", t.Min()),
+			"max":       fmt.Sprintf("%d", t.Max()),
+			"mean":      fmt.Sprintf("%f", t.Mean()),
+			"stddev":    fmt.Sprintf("%f", t.StdDev()),
+			"p50":       fmt.Sprintf("%f", ps[0]),
+			"p75":       fmt.Sprintf("%f", ps[1]),
+			"p95":       fmt.Sprintf("%f", ps[2]),
+			"p99":       fmt.Sprintf("%f", ps[3]),
+			"p999":      fmt.Sprintf("%f", ps[4]),
+			"m1":        fmt.Sprintf("%f", t.Rate1()),
+			"m5":        fmt.Sprintf("%f", t.Rate5()),
+			"m15":       fmt.Sprintf("%f", t.Rate15()),
+			"mean_rate": fmt.Sprintf("%f", t.RateMean()),
+		})
+	})
+
+	r.reg.EachResettingTimer(func(name string, t metrics.ResettingTimer) {
+		s := t.Snapshot()
+		values := s.Values()
+		ps := s.Percentiles([]float64{50, 75, 95, 99, 99.9})
+		r.writeLine(&buf, name, now, map[string]string{
+			"count": fmt.Sprintf("%d", len(values)),
+			"mean":  fmt.Sprintf("%f", s.Mean()),
+			"p50":   fmt.Sprintf("%f", ps[0]),
+			"p75":   fmt.Sprintf("%f", ps[1]),
+			"p95":   fmt.Sprintf("%f", ps[2]),
+			"p99":   fmt.Sprintf("%f", ps[3]),
+			"p999":  fmt.Sprintf("%f", ps[4]),
+		})
+	})
+
+	if buf.Len() == 0 {
+		return nil
+	}
+	return r.client.writeWithRetry(buf.Bytes())
+}
+
+// writeLine appends a single InfluxDB line-protocol measurement for name to
+// buf, attaching the reporter's tags plus the given fields.
+func (r *reporter) writeLine(buf *bytes.Buffer, name string, t time.Time, fields map[string]string) {
+	buf.WriteString(name)
+	for k, v := range r.tags {
+		buf.WriteByte(',')
+		buf.WriteString(k)
+		buf.WriteByte('=')
+		buf.WriteString(v)
+	}
+	buf.WriteByte(' ')
+	first := true
+	for k, v := range fields {
+		if !first {
+			buf.WriteByte(',')
+		}
+		first = false
+		buf.WriteString(k)
+		buf.WriteByte('=')
+		buf.WriteString(v)
+	}
+	buf.WriteByte(' ')
+	fmt.Fprintf(buf, "%d\n", t.UnixNano())
+}
@@ -0,0 +1,74 @@
+package influxdb
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mia0x75/go-metrics"
+)
+
+// fixedClock is a deterministic stand-in for the reporter's clock seam, so
+// tests don't depend on wall-clock time.
+type fixedClock struct {
+	now time.Time
+}
+
+func (c fixedClock) Now() time.Time { return c.now }
+
+// captureWriter records whatever payload send() hands to the client,
+// instead of making an HTTP request.
+type captureWriter struct {
+	payload []byte
+}
+
+func (w *captureWriter) writeWithRetry(p []byte) error {
+	w.payload = append([]byte(nil), p...)
+	return nil
+}
+
+func TestReporterSendUsesInjectedClock(t *testing.T) {
+	reg := metrics.NewRegistry()
+	c := metrics.GetOrRegisterCounter("requests", reg)
+	c.Inc(7)
+
+	fc := fixedClock{now: time.Unix(1600000000, 0)}
+	cw := &captureWriter{}
+	rep := &reporter{
+		reg:    reg,
+		clock:  fc,
+		client: cw,
+	}
+
+	if err := rep.send(); err != nil {
+		t.Fatalf("send() returned an error: %v", err)
+	}
+
+	payload := string(cw.payload)
+	if !strings.Contains(payload, "requests") || !strings.Contains(payload, "count=7") {
+		t.Fatalf("expected payload to contain the counter's count, got: %q", payload)
+	}
+
+	wantTS := strconv.FormatInt(fc.Now().UnixNano(), 10)
+	if !strings.Contains(payload, wantTS) {
+		t.Fatalf("expected payload to use the injected clock's timestamp %s, got: %q", wantTS, payload)
+	}
+}
+
+func TestReporterSendNoMetricsIsNoop(t *testing.T) {
+	reg := metrics.NewRegistry()
+	cw := &captureWriter{}
+	rep := &reporter{
+		reg:    reg,
+		clock:  fixedClock{now: time.Now()},
+		client: cw,
+	}
+
+	if err := rep.send(); err != nil {
+		t.Fatalf("send() returned an error: %v", err)
+	}
+	if cw.payload != nil {
+		t.Fatalf("expected no write when the registry is empty, got: %q", cw.payload)
+	}
+}
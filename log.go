@@ -44,6 +44,19 @@ func Log(r Registry, interval int, l *log.Logger) {
 			l.Printf("  15-min rate: %12.2f\n", m.Rate15())
 			l.Printf("  mean rate:   %12.2f\n", m.RateMean())
 		})
+		r.EachResettingTimer(func(name string, t ResettingTimer) {
+			s := t.Snapshot()
+			values := s.Values()
+			ps := s.Percentiles([]float64{50, 75, 95, 99, 99.9})
+			l.Printf("resetting timer %s\n", name)
+			l.Printf("  count:       %9d\n", len(values))
+			l.Printf("  mean:        %12.2f\n", s.Mean())
+			l.Printf("  median:      %12.2f\n", ps[0])
+			l.Printf("  75%%:         %12.2f\n", ps[1])
+			l.Printf("  95%%:         %12.2f\n", ps[2])
+			l.Printf("  99%%:         %12.2f\n", ps[3])
+			l.Printf("  99.9%%:       %12.2f\n", ps[4])
+		})
 		r.EachTimer(func(name string, t Timer) {
 			ps := t.Percentiles([]float64{0.5, 0.75, 0.95, 0.99, 0.999})
 			l.Printf("timer %s\n", name)
@@ -138,6 +138,12 @@ type StandardMeter struct {
 	a1, a5, a15 EWMA
 	startTime   time.Time
 	stopped     uint32
+
+	// uncounted holds events marked since the last arbiter tick. Mark only
+	// ever adds to it atomically; the arbiter drains it into the EWMAs
+	// every 5 seconds so Mark never blocks on m.lock or touches the EWMAs
+	// directly.
+	uncounted int64
 }
 
 func newStandardMeter() *StandardMeter {
@@ -169,18 +175,16 @@ func (m *StandardMeter) Count() int64 {
 	return atomic.LoadInt64(&m.snapshot.count)
 }
 
-// Mark records the occurance of n events.
+// Mark records the occurance of n events. It never blocks: the count and
+// the EWMA input are both updated with a plain atomic add, and the EWMAs
+// themselves are only touched by the meterArbiter's periodic tick.
 func (m *StandardMeter) Mark(n int64) {
 	if atomic.LoadUint32(&m.stopped) == 1 {
 		return
 	}
 
+	atomic.AddInt64(&m.uncounted, n)
 	atomic.AddInt64(&m.snapshot.count, n)
-
-	m.a1.Update(n)
-	m.a5.Update(n)
-	m.a15.Update(n)
-	m.updateSnapshot()
 }
 
 // Rate1 returns the one-minute moving average rate of events per second.
@@ -263,7 +267,17 @@ func (m *StandardMeter) updateSnapshotOnStep() {
 	m.snapshot._lastTime = nw
 }
 
+// tick drains the events accumulated by Mark since the last tick into the
+// EWMAs and advances them. It holds m.lock for the duration, the same lock
+// RateStep and Snapshot take before reading a1/a5/a15 directly, so a
+// concurrent call can't observe the EWMAs mid-update.
 func (m *StandardMeter) tick(now time.Time) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	n := atomic.SwapInt64(&m.uncounted, 0)
+	m.a1.Update(n)
+	m.a5.Update(n)
+	m.a15.Update(n)
 	m.a1.Tick()
 	m.a5.Tick()
 	m.a15.Tick()
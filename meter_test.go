@@ -0,0 +1,51 @@
+package metrics
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestStandardMeterMarkAndTick verifies that Mark's atomic bookkeeping and
+// the arbiter's tick agree: Count must reflect marked events immediately,
+// uncounted must hold exactly what hasn't been drained into the EWMAs yet,
+// and a manual tick must drain it and produce a non-zero rate.
+func TestStandardMeterMarkAndTick(t *testing.T) {
+	m := newStandardMeter()
+
+	m.Mark(47)
+	m.Mark(3)
+
+	if count := m.Count(); count != 50 {
+		t.Fatalf("Count() = %d, want 50", count)
+	}
+	if u := atomic.LoadInt64(&m.uncounted); u != 50 {
+		t.Fatalf("uncounted = %d, want 50 before the arbiter ticks", u)
+	}
+
+	m.tick(time.Now())
+
+	if u := atomic.LoadInt64(&m.uncounted); u != 0 {
+		t.Fatalf("uncounted = %d, want 0 after tick drains it", u)
+	}
+	if count := m.Count(); count != 50 {
+		t.Fatalf("Count() = %d, want 50 after tick", count)
+	}
+	if rate := m.Rate1(); rate <= 0 {
+		t.Fatalf("Rate1() = %f, want > 0 after marking events and ticking", rate)
+	}
+}
+
+// BenchmarkMarkConcurrent exercises StandardMeter.Mark from many goroutines
+// at once. It exists to demonstrate that Mark no longer contends on a lock
+// or on the EWMAs: throughput should scale with GOMAXPROCS instead of
+// flattening out once multiple producers are marking the same meter.
+func BenchmarkMarkConcurrent(b *testing.B) {
+	m := newStandardMeter()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			m.Mark(1)
+		}
+	})
+}
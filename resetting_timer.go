@@ -0,0 +1,257 @@
+package metrics
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// InitialResettingTimerSliceCap is the initial capacity for the slice of
+// values held by a ResettingTimer.
+const InitialResettingTimerSliceCap = 10
+
+// ResettingTimer is used for storing aggregated data such as counts, min,
+// max, mean, and percentiles for timers, but unlike the Timer type,
+// ResettingTimer does not maintain a decaying sample: its values are
+// cleared on every Snapshot, so percentiles reflect exactly the interval
+// since the previous snapshot rather than a smoothed, historical estimate.
+//
+// This is primarily intended for reporting rates/latencies at a regular
+// interval (e.g. to a stats aggregator such as InfluxDB or Graphite) where
+// the raw sample is reset on each report.
+type ResettingTimer interface {
+	Values() []int64
+	Snapshot() ResettingTimer
+	// Percentiles takes percentiles on a 0-100 scale (50, 95, 99.9), not
+	// the 0-1 fractions Histogram/Timer use.
+	Percentiles([]float64) []float64
+	Mean() float64
+	Time(func())
+	Update(time.Duration)
+	UpdateSince(time.Time)
+}
+
+// GetOrRegisterResettingTimer returns an existing ResettingTimer or
+// constructs and registers a new StandardResettingTimer.
+func GetOrRegisterResettingTimer(name string, r Registry) ResettingTimer {
+	if nil == r {
+		r = DefaultRegistry
+	}
+	return r.GetOrRegister(name, NewResettingTimer).(ResettingTimer)
+}
+
+// NewResettingTimer constructs a new StandardResettingTimer.
+func NewResettingTimer() ResettingTimer {
+	if UseNilMetrics {
+		return NilResettingTimer{}
+	}
+	return &StandardResettingTimer{
+		values: make([]int64, 0, InitialResettingTimerSliceCap),
+	}
+}
+
+// NewRegisteredResettingTimer constructs and registers a new
+// StandardResettingTimer.
+func NewRegisteredResettingTimer(name string, r Registry) ResettingTimer {
+	c := NewResettingTimer()
+	if nil == r {
+		r = DefaultRegistry
+	}
+	r.Register(name, c)
+	return c
+}
+
+// ResettingTimerSnapshot is a read-only copy of a ResettingTimer, holding
+// the raw values recorded since the previous snapshot.
+type ResettingTimerSnapshot struct {
+	values              []int64
+	mean                float64
+	min, max            int64
+	thresholdBoundaries []float64
+	calculated          bool
+}
+
+// Snapshot returns the snapshot.
+func (t *ResettingTimerSnapshot) Snapshot() ResettingTimer { return t }
+
+// Time panics.
+func (*ResettingTimerSnapshot) Time(func()) {
+	panic("Time called on a ResettingTimerSnapshot")
+}
+
+// Update panics.
+func (*ResettingTimerSnapshot) Update(time.Duration) {
+	panic("Update called on a ResettingTimerSnapshot")
+}
+
+// UpdateSince panics.
+func (*ResettingTimerSnapshot) UpdateSince(time.Time) {
+	panic("UpdateSince called on a ResettingTimerSnapshot")
+}
+
+// Values returns all values recorded since the previous snapshot.
+func (t *ResettingTimerSnapshot) Values() []int64 { return t.values }
+
+// Percentiles returns the boundaries for the given percentiles.
+func (t *ResettingTimerSnapshot) Percentiles(percentiles []float64) []float64 {
+	t.calc(percentiles)
+	return t.thresholdBoundaries
+}
+
+// Mean returns the mean of the values recorded since the previous snapshot.
+func (t *ResettingTimerSnapshot) Mean() float64 {
+	if !t.calculated {
+		t.calc(nil)
+	}
+	return t.mean
+}
+
+// Min returns the minimum of the values recorded since the previous snapshot.
+func (t *ResettingTimerSnapshot) Min() int64 {
+	if !t.calculated {
+		t.calc(nil)
+	}
+	return t.min
+}
+
+// Max returns the maximum of the values recorded since the previous snapshot.
+func (t *ResettingTimerSnapshot) Max() int64 {
+	if !t.calculated {
+		t.calc(nil)
+	}
+	return t.max
+}
+
+func (t *ResettingTimerSnapshot) calc(percentiles []float64) {
+	sort.Sort(int64Slice(t.values))
+
+	count := len(t.values)
+	t.thresholdBoundaries = make([]float64, len(percentiles))
+	if count == 0 {
+		t.calculated = true
+		return
+	}
+
+	min := t.values[0]
+	max := t.values[count-1]
+
+	var sum int64
+	for _, v := range t.values {
+		sum += v
+	}
+
+	for i, pct := range percentiles {
+		thresholdBoundary := max
+		if count > 1 {
+			var abs float64
+			if pct >= 0 {
+				abs = pct
+			} else {
+				abs = 100 + pct
+			}
+			indexOfPerc := int(math.Ceil(abs/100.0*float64(count))) - 1
+			if indexOfPerc >= 0 {
+				if indexOfPerc > count-1 {
+					indexOfPerc = count - 1
+				}
+				thresholdBoundary = t.values[indexOfPerc]
+			}
+		}
+		t.thresholdBoundaries[i] = float64(thresholdBoundary)
+	}
+
+	t.mean = float64(sum) / float64(count)
+	t.min = min
+	t.max = max
+	t.calculated = true
+}
+
+// int64Slice attaches the methods of sort.Interface to []int64.
+type int64Slice []int64
+
+func (p int64Slice) Len() int           { return len(p) }
+func (p int64Slice) Less(i, j int) bool { return p[i] < p[j] }
+func (p int64Slice) Swap(i, j int)      { p[i], p[j] = p[j], p[i] }
+
+// NilResettingTimer is a no-op ResettingTimer.
+type NilResettingTimer struct{}
+
+// Time is a no-op.
+func (NilResettingTimer) Time(func()) {}
+
+// Update is a no-op.
+func (NilResettingTimer) Update(time.Duration) {}
+
+// Values is a no-op.
+func (NilResettingTimer) Values() []int64 { return nil }
+
+// UpdateSince is a no-op.
+func (NilResettingTimer) UpdateSince(time.Time) {}
+
+// Snapshot is a no-op.
+func (NilResettingTimer) Snapshot() ResettingTimer { return NilResettingTimer{} }
+
+// Percentiles is a no-op.
+func (NilResettingTimer) Percentiles([]float64) []float64 { return nil }
+
+// Mean is a no-op.
+func (NilResettingTimer) Mean() float64 { return 0.0 }
+
+// StandardResettingTimer is the standard implementation of a
+// ResettingTimer. Unlike StandardTimer it keeps no decaying sample: it
+// simply appends every recorded value to a slice and clears the slice on
+// each Snapshot.
+type StandardResettingTimer struct {
+	values []int64
+	mutex  sync.Mutex
+}
+
+// Values returns the values recorded since the previous snapshot.
+func (t *StandardResettingTimer) Values() []int64 {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return t.values
+}
+
+// Snapshot resets the timer and returns a read-only copy of the values
+// recorded since the previous snapshot.
+func (t *StandardResettingTimer) Snapshot() ResettingTimer {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	currentValues := t.values
+	t.values = make([]int64, 0, InitialResettingTimerSliceCap)
+	return &ResettingTimerSnapshot{values: currentValues}
+}
+
+// Percentiles panics.
+func (t *StandardResettingTimer) Percentiles([]float64) []float64 {
+	panic("Percentiles called on a StandardResettingTimer")
+}
+
+// Mean panics.
+func (t *StandardResettingTimer) Mean() float64 {
+	panic("Mean called on a StandardResettingTimer")
+}
+
+// Time records the duration of the execution of the given function.
+func (t *StandardResettingTimer) Time(f func()) {
+	ts := time.Now()
+	f()
+	t.Update(time.Since(ts))
+}
+
+// Update records the duration of an event.
+func (t *StandardResettingTimer) Update(d time.Duration) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.values = append(t.values, int64(d))
+}
+
+// UpdateSince records the duration of an event that started at ts and ends
+// now.
+func (t *StandardResettingTimer) UpdateSince(ts time.Time) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.values = append(t.values, int64(time.Since(ts)))
+}
@@ -0,0 +1,98 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStandardResettingTimerComputesPercentilesMinMaxMean(t *testing.T) {
+	timer := NewResettingTimer()
+	for i := int64(1); i <= 10; i++ {
+		timer.Update(time.Duration(i))
+	}
+
+	snapshot := timer.Snapshot()
+
+	if got := snapshot.Mean(); got != 5.5 {
+		t.Fatalf("Mean() = %v, want 5.5", got)
+	}
+
+	ps := snapshot.Percentiles([]float64{50, 99})
+	if got := ps[0]; got != 5 {
+		t.Fatalf("50th percentile = %v, want 5", got)
+	}
+	if got := ps[1]; got != 10 {
+		t.Fatalf("99th percentile = %v, want 10", got)
+	}
+
+	values := snapshot.Values()
+	if len(values) != 10 {
+		t.Fatalf("len(Values()) = %d, want 10", len(values))
+	}
+	var min, max int64 = values[0], values[0]
+	for _, v := range values {
+		if v < min {
+			min = v
+		}
+		if v > max {
+			max = v
+		}
+	}
+	if min != 1 {
+		t.Fatalf("min value = %d, want 1", min)
+	}
+	if max != 10 {
+		t.Fatalf("max value = %d, want 10", max)
+	}
+}
+
+func TestStandardResettingTimerResetsOnSnapshot(t *testing.T) {
+	timer := NewResettingTimer().(*StandardResettingTimer)
+	timer.Update(1)
+	timer.Update(2)
+	timer.Update(3)
+
+	snapshot := timer.Snapshot()
+
+	if got := len(snapshot.Values()); got != 3 {
+		t.Fatalf("snapshot len(Values()) = %d, want 3", got)
+	}
+	if got := len(timer.Values()); got != 0 {
+		t.Fatalf("timer len(Values()) = %d, want 0 immediately after Snapshot", got)
+	}
+
+	timer.Update(4)
+	if got := len(timer.Values()); got != 1 {
+		t.Fatalf("timer len(Values()) = %d, want 1 after a single post-snapshot Update", got)
+	}
+}
+
+func TestStandardResettingTimerEmptySnapshot(t *testing.T) {
+	timer := NewResettingTimer()
+	snapshot := timer.Snapshot()
+
+	if got := len(snapshot.Values()); got != 0 {
+		t.Fatalf("len(Values()) = %d, want 0 for an empty timer", got)
+	}
+	if got := snapshot.Mean(); got != 0 {
+		t.Fatalf("Mean() = %v, want 0 for an empty timer", got)
+	}
+	ps := snapshot.Percentiles([]float64{50})
+	if got := ps[0]; got != 0 {
+		t.Fatalf("50th percentile = %v, want 0 for an empty timer", got)
+	}
+}
+
+func TestNilResettingTimer(t *testing.T) {
+	var timer ResettingTimer = NilResettingTimer{}
+	timer.Update(time.Second)
+	timer.UpdateSince(time.Now())
+	timer.Time(func() {})
+
+	if got := timer.Values(); got != nil {
+		t.Fatalf("Values() = %v, want nil", got)
+	}
+	if got := timer.Mean(); got != 0 {
+		t.Fatalf("Mean() = %v, want 0", got)
+	}
+}
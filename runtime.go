@@ -0,0 +1,189 @@
+package metrics
+
+import (
+	"runtime"
+	"runtime/pprof"
+	"sync"
+	"time"
+)
+
+var (
+	// runtimeMetricsMu serialises CaptureRuntimeMemStatsOnce, which reads
+	// into and updates the package-level state below.
+	runtimeMetricsMu sync.Mutex
+
+	memStats       runtime.MemStats
+	runtimeMetrics struct {
+		MemStats struct {
+			Alloc         Gauge
+			BuckHashSys   Gauge
+			DebugGC       Gauge
+			EnableGC      Gauge
+			Frees         Gauge
+			HeapAlloc     Gauge
+			HeapIdle      Gauge
+			HeapInuse     Gauge
+			HeapObjects   Gauge
+			HeapReleased  Gauge
+			HeapSys       Gauge
+			LastGC        Gauge
+			Lookups       Gauge
+			Mallocs       Gauge
+			MCacheInuse   Gauge
+			MCacheSys     Gauge
+			MSpanInuse    Gauge
+			MSpanSys      Gauge
+			NextGC        Gauge
+			NumGC         Meter
+			GCCPUFraction GaugeFloat64
+			PauseNs       Histogram
+			PauseTotalNs  Meter
+			StackInuse    Gauge
+			StackSys      Gauge
+			Sys           Gauge
+			TotalAlloc    Gauge
+		}
+		NumCgoCall   Gauge
+		NumGoroutine Gauge
+		NumThread    Gauge
+		ReadMemStats Timer
+	}
+	frees        uint64
+	lookups      uint64
+	mallocs      uint64
+	numGC        uint32
+	numCgoCalls  int64
+	pauseTotalNs uint64
+)
+
+// CaptureRuntimeMemStats is a blocking function, intended to be run in its
+// own goroutine (e.g. `go metrics.CaptureRuntimeMemStats(r, interval)`),
+// that calls CaptureRuntimeMemStatsOnce against r every interval.
+func CaptureRuntimeMemStats(r Registry, interval time.Duration) {
+	for range time.Tick(interval) {
+		CaptureRuntimeMemStatsOnce(r)
+	}
+}
+
+// CaptureRuntimeMemStatsOnce registers the runtime.* gauges, meters, and
+// histograms against r if they have not been already, then takes a single
+// sample of the Go runtime's memory and scheduler statistics and updates
+// them. It is safe to call repeatedly and from multiple goroutines.
+//
+// Be careful calling this too often: runtime.ReadMemStats stops the world
+// to collect its sample.
+func CaptureRuntimeMemStatsOnce(r Registry) {
+	runtimeMetricsMu.Lock()
+	defer runtimeMetricsMu.Unlock()
+	registerRuntimeMemStats(r)
+
+	t := time.Now()
+	runtime.ReadMemStats(&memStats)
+	runtimeMetrics.ReadMemStats.UpdateSince(t)
+
+	runtimeMetrics.MemStats.Alloc.Update(int64(memStats.Alloc))
+	runtimeMetrics.MemStats.BuckHashSys.Update(int64(memStats.BuckHashSys))
+	if memStats.DebugGC {
+		runtimeMetrics.MemStats.DebugGC.Update(1)
+	} else {
+		runtimeMetrics.MemStats.DebugGC.Update(0)
+	}
+	if memStats.EnableGC {
+		runtimeMetrics.MemStats.EnableGC.Update(1)
+	} else {
+		runtimeMetrics.MemStats.EnableGC.Update(0)
+	}
+
+	runtimeMetrics.MemStats.Frees.Update(int64(memStats.Frees - frees))
+	runtimeMetrics.MemStats.HeapAlloc.Update(int64(memStats.HeapAlloc))
+	runtimeMetrics.MemStats.HeapIdle.Update(int64(memStats.HeapIdle))
+	runtimeMetrics.MemStats.HeapInuse.Update(int64(memStats.HeapInuse))
+	runtimeMetrics.MemStats.HeapObjects.Update(int64(memStats.HeapObjects))
+	runtimeMetrics.MemStats.HeapReleased.Update(int64(memStats.HeapReleased))
+	runtimeMetrics.MemStats.HeapSys.Update(int64(memStats.HeapSys))
+	runtimeMetrics.MemStats.LastGC.Update(int64(memStats.LastGC))
+	runtimeMetrics.MemStats.Lookups.Update(int64(memStats.Lookups - lookups))
+	runtimeMetrics.MemStats.Mallocs.Update(int64(memStats.Mallocs - mallocs))
+	runtimeMetrics.MemStats.MCacheInuse.Update(int64(memStats.MCacheInuse))
+	runtimeMetrics.MemStats.MCacheSys.Update(int64(memStats.MCacheSys))
+	runtimeMetrics.MemStats.MSpanInuse.Update(int64(memStats.MSpanInuse))
+	runtimeMetrics.MemStats.MSpanSys.Update(int64(memStats.MSpanSys))
+	runtimeMetrics.MemStats.NextGC.Update(int64(memStats.NextGC))
+	runtimeMetrics.MemStats.NumGC.Mark(int64(memStats.NumGC - numGC))
+	readGCCPUFraction(&memStats)
+
+	// memStats.PauseNs is a circular buffer of the last 256 GC pause
+	// durations; walk only the entries recorded since the last sample.
+	i := numGC % uint32(len(memStats.PauseNs))
+	ii := memStats.NumGC % uint32(len(memStats.PauseNs))
+	if memStats.NumGC-numGC >= uint32(len(memStats.PauseNs)) {
+		for i = 0; i < uint32(len(memStats.PauseNs)); i++ {
+			runtimeMetrics.MemStats.PauseNs.Update(int64(memStats.PauseNs[i]))
+		}
+	} else {
+		if ii < i {
+			for ; i < uint32(len(memStats.PauseNs)); i++ {
+				runtimeMetrics.MemStats.PauseNs.Update(int64(memStats.PauseNs[i]))
+			}
+			i = 0
+		}
+		for ; i < ii; i++ {
+			runtimeMetrics.MemStats.PauseNs.Update(int64(memStats.PauseNs[i]))
+		}
+	}
+	frees = memStats.Frees
+	lookups = memStats.Lookups
+	mallocs = memStats.Mallocs
+	numGC = memStats.NumGC
+
+	runtimeMetrics.MemStats.PauseTotalNs.Mark(int64(memStats.PauseTotalNs - pauseTotalNs))
+	pauseTotalNs = memStats.PauseTotalNs
+	runtimeMetrics.MemStats.StackInuse.Update(int64(memStats.StackInuse))
+	runtimeMetrics.MemStats.StackSys.Update(int64(memStats.StackSys))
+	runtimeMetrics.MemStats.Sys.Update(int64(memStats.Sys))
+	runtimeMetrics.MemStats.TotalAlloc.Update(int64(memStats.TotalAlloc))
+
+	currentCgoCalls := runtime.NumCgoCall()
+	runtimeMetrics.NumCgoCall.Update(currentCgoCalls - numCgoCalls)
+	numCgoCalls = currentCgoCalls
+
+	runtimeMetrics.NumGoroutine.Update(int64(runtime.NumGoroutine()))
+
+	if threadProfile := pprof.Lookup("threadcreate"); threadProfile != nil {
+		runtimeMetrics.NumThread.Update(int64(threadProfile.Count()))
+	}
+}
+
+func registerRuntimeMemStats(r Registry) {
+	runtimeMetrics.MemStats.Alloc = GetOrRegisterGauge("runtime.MemStats.Alloc", r)
+	runtimeMetrics.MemStats.BuckHashSys = GetOrRegisterGauge("runtime.MemStats.BuckHashSys", r)
+	runtimeMetrics.MemStats.DebugGC = GetOrRegisterGauge("runtime.MemStats.DebugGC", r)
+	runtimeMetrics.MemStats.EnableGC = GetOrRegisterGauge("runtime.MemStats.EnableGC", r)
+	runtimeMetrics.MemStats.Frees = GetOrRegisterGauge("runtime.MemStats.Frees", r)
+	runtimeMetrics.MemStats.HeapAlloc = GetOrRegisterGauge("runtime.MemStats.HeapAlloc", r)
+	runtimeMetrics.MemStats.HeapIdle = GetOrRegisterGauge("runtime.MemStats.HeapIdle", r)
+	runtimeMetrics.MemStats.HeapInuse = GetOrRegisterGauge("runtime.MemStats.HeapInuse", r)
+	runtimeMetrics.MemStats.HeapObjects = GetOrRegisterGauge("runtime.MemStats.HeapObjects", r)
+	runtimeMetrics.MemStats.HeapReleased = GetOrRegisterGauge("runtime.MemStats.HeapReleased", r)
+	runtimeMetrics.MemStats.HeapSys = GetOrRegisterGauge("runtime.MemStats.HeapSys", r)
+	runtimeMetrics.MemStats.LastGC = GetOrRegisterGauge("runtime.MemStats.LastGC", r)
+	runtimeMetrics.MemStats.Lookups = GetOrRegisterGauge("runtime.MemStats.Lookups", r)
+	runtimeMetrics.MemStats.Mallocs = GetOrRegisterGauge("runtime.MemStats.Mallocs", r)
+	runtimeMetrics.MemStats.MCacheInuse = GetOrRegisterGauge("runtime.MemStats.MCacheInuse", r)
+	runtimeMetrics.MemStats.MCacheSys = GetOrRegisterGauge("runtime.MemStats.MCacheSys", r)
+	runtimeMetrics.MemStats.MSpanInuse = GetOrRegisterGauge("runtime.MemStats.MSpanInuse", r)
+	runtimeMetrics.MemStats.MSpanSys = GetOrRegisterGauge("runtime.MemStats.MSpanSys", r)
+	runtimeMetrics.MemStats.NextGC = GetOrRegisterGauge("runtime.MemStats.NextGC", r)
+	runtimeMetrics.MemStats.NumGC = GetOrRegisterMeter("runtime.MemStats.NumGC", r)
+	runtimeMetrics.MemStats.GCCPUFraction = GetOrRegisterGaugeFloat64("runtime.MemStats.GCCPUFraction", r)
+	runtimeMetrics.MemStats.PauseNs = GetOrRegisterHistogram("runtime.MemStats.PauseNs", r, NewExpDecaySample(1028, 0.015))
+	runtimeMetrics.MemStats.PauseTotalNs = GetOrRegisterMeter("runtime.MemStats.PauseTotalNs", r)
+	runtimeMetrics.MemStats.StackInuse = GetOrRegisterGauge("runtime.MemStats.StackInuse", r)
+	runtimeMetrics.MemStats.StackSys = GetOrRegisterGauge("runtime.MemStats.StackSys", r)
+	runtimeMetrics.MemStats.Sys = GetOrRegisterGauge("runtime.MemStats.Sys", r)
+	runtimeMetrics.MemStats.TotalAlloc = GetOrRegisterGauge("runtime.MemStats.TotalAlloc", r)
+	runtimeMetrics.NumCgoCall = GetOrRegisterGauge("runtime.NumCgoCall", r)
+	runtimeMetrics.NumGoroutine = GetOrRegisterGauge("runtime.NumGoroutine", r)
+	runtimeMetrics.NumThread = GetOrRegisterGauge("runtime.NumThread", r)
+	runtimeMetrics.ReadMemStats = GetOrRegisterTimer("runtime.ReadMemStats", r)
+}
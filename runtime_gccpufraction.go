@@ -0,0 +1,9 @@
+// +build go1.5
+
+package metrics
+
+import "runtime"
+
+func readGCCPUFraction(memStats *runtime.MemStats) {
+	runtimeMetrics.MemStats.GCCPUFraction.Update(memStats.GCCPUFraction)
+}
@@ -0,0 +1,9 @@
+// +build !go1.5
+
+package metrics
+
+import "runtime"
+
+// GCCPUFraction was added in Go 1.5; on older toolchains there is nothing
+// to read so readGCCPUFraction is a no-op.
+func readGCCPUFraction(memStats *runtime.MemStats) {}
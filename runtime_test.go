@@ -0,0 +1,38 @@
+package metrics
+
+import "testing"
+
+func TestCaptureRuntimeMemStatsOnceRegistersAndUpdatesMetrics(t *testing.T) {
+	r := NewRegistry()
+
+	CaptureRuntimeMemStatsOnce(r)
+
+	if g, ok := r.Get("runtime.MemStats.HeapAlloc").(Gauge); !ok || g.Value() <= 0 {
+		t.Fatalf("runtime.MemStats.HeapAlloc = %v, want a registered Gauge with a positive value", r.Get("runtime.MemStats.HeapAlloc"))
+	}
+	if h, ok := r.Get("runtime.MemStats.PauseNs").(Histogram); !ok || h == nil {
+		t.Fatalf("runtime.MemStats.PauseNs = %v, want a registered Histogram", r.Get("runtime.MemStats.PauseNs"))
+	}
+	if m, ok := r.Get("runtime.MemStats.NumGC").(Meter); !ok || m == nil {
+		t.Fatalf("runtime.MemStats.NumGC = %v, want a registered Meter", r.Get("runtime.MemStats.NumGC"))
+	}
+	if m, ok := r.Get("runtime.MemStats.PauseTotalNs").(Meter); !ok || m == nil {
+		t.Fatalf("runtime.MemStats.PauseTotalNs = %v, want a registered Meter", r.Get("runtime.MemStats.PauseTotalNs"))
+	}
+	timer, ok := r.Get("runtime.ReadMemStats").(Timer)
+	if !ok || timer == nil {
+		t.Fatalf("runtime.ReadMemStats = %v, want a registered Timer", r.Get("runtime.ReadMemStats"))
+	}
+	if count := timer.Count(); count != 1 {
+		t.Fatalf("runtime.ReadMemStats count = %d, want 1 after a single CaptureRuntimeMemStatsOnce call", count)
+	}
+
+	CaptureRuntimeMemStatsOnce(r)
+
+	if count := timer.Count(); count != 2 {
+		t.Fatalf("runtime.ReadMemStats count = %d, want 2 after a second CaptureRuntimeMemStatsOnce call", count)
+	}
+	if g, ok := r.Get("runtime.NumGoroutine").(Gauge); !ok || g.Value() <= 0 {
+		t.Fatalf("runtime.NumGoroutine = %v, want a registered Gauge with a positive value", r.Get("runtime.NumGoroutine"))
+	}
+}